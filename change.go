@@ -1,123 +1,238 @@
 package xes_to_csv
 
 import (
-	"encoding/csv"
 	"encoding/xml"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 // XES represents the structure of the XES file.
 type XES struct {
-	XMLName xml.Name `xml:"log"`
-	Traces  []Trace  `xml:"trace"` // Changed variable name to plural form for consistency.
+	XMLName    xml.Name    `xml:"log"`
+	Extensions []Extension `xml:"extension"`
+	Traces     []Trace     `xml:"trace"` // Changed variable name to plural form for consistency.
 }
 
-// Trace represents a single trace in the XES file.
+// Extension declares one of the standard XES extensions a log uses, e.g.
+// <extension name="Concept" prefix="concept" uri="..."/>.
+type Extension struct {
+	Name   string `xml:"name,attr"`
+	Prefix string `xml:"prefix,attr"`
+	URI    string `xml:"uri,attr"`
+}
+
+// Trace represents a single trace in the XES file. Attributes is embedded
+// before Events so that when a Trace is marshaled back to XML, its own
+// attributes precede its events, matching what the streaming decoder
+// (and the XES convention generally) expects to find.
 type Trace struct {
-	Events           []Event           `xml:"event"` // Changed variable name to plural form for consistency.
-	StringAttributes []StringAttribute `xml:"string"`
+	Attributes
+	Events []Event `xml:"event"` // Changed variable name to plural form for consistency.
 }
 
 // Event represents a single event within a trace in the XES file.
 type Event struct {
-	StringAttributes []StringAttribute `xml:"string"`
-	DateAttributes   []DateAttribute   `xml:"date"`
+	Attributes
 }
 
-// StringAttribute represents a string attribute in an event or trace.
-type StringAttribute struct {
-	Key   string `xml:"key,attr"`
-	Value string `xml:"value,attr"`
+// Attribute is implemented by every XES attribute type (string, int, float,
+// boolean, id, date, list, container), so callers can walk a trace or
+// event's attributes without a type switch on each concrete kind.
+type Attribute interface {
+	// Key returns the attribute's own key, without any dotted parent prefix
+	// contributed by an enclosing list or container.
+	Key() string
+	// StringValue renders the attribute's value as a string suitable for a
+	// CSV cell. List and container attributes have no value of their own
+	// and return an empty string; their contents are reached through All.
+	StringValue() string
+	// Type returns the XES element name the attribute was decoded from,
+	// e.g. "string", "int", "list".
+	Type() string
 }
 
-// DateAttribute represents a date attribute in an event.
-type DateAttribute struct {
-	Key   string `xml:"key,attr"`
-	Value string `xml:"value,attr"`
+// Attributes holds every attribute kind XES defines, keyed by the XML
+// element name it was decoded from. Trace, Event, ListAttribute and
+// ContainerAttribute all embed it so they share one unmarshalling and
+// traversal implementation.
+type Attributes struct {
+	StringAttributes    []StringAttribute    `xml:"string"`
+	IntAttributes       []IntAttribute       `xml:"int"`
+	FloatAttributes     []FloatAttribute     `xml:"float"`
+	BooleanAttributes   []BooleanAttribute   `xml:"boolean"`
+	IDAttributes        []IDAttribute        `xml:"id"`
+	DateAttributes      []DateAttribute      `xml:"date"`
+	ListAttributes      []ListAttribute      `xml:"list"`
+	ContainerAttributes []ContainerAttribute `xml:"container"`
 }
 
-// ConvertXESToCSV reads an XES file and writes its contents to a CSV file.
-func ConvertXESToCSV(XESFilePath, CSVFilePath string) error {
-	// Validate and clean the input file path.
-	inputPath := filepath.Clean(XESFilePath)
-	if !isValidXESFile(inputPath) {
-		return fmt.Errorf("input file must be an XES file: %s", inputPath)
+// All returns every attribute held directly by this container, as the
+// common Attribute interface, grouped by type in document order.
+func (a Attributes) All() []Attribute {
+	attrs := make([]Attribute, 0, len(a.StringAttributes)+len(a.IntAttributes)+len(a.FloatAttributes)+
+		len(a.BooleanAttributes)+len(a.IDAttributes)+len(a.DateAttributes)+len(a.ListAttributes)+len(a.ContainerAttributes))
+	for _, v := range a.StringAttributes {
+		attrs = append(attrs, v)
 	}
-
-	// Open the XES file.
-	xesFile, err := os.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("failed to open XES file: %w", err)
+	for _, v := range a.IntAttributes {
+		attrs = append(attrs, v)
+	}
+	for _, v := range a.FloatAttributes {
+		attrs = append(attrs, v)
+	}
+	for _, v := range a.BooleanAttributes {
+		attrs = append(attrs, v)
+	}
+	for _, v := range a.IDAttributes {
+		attrs = append(attrs, v)
+	}
+	for _, v := range a.DateAttributes {
+		attrs = append(attrs, v)
 	}
-	defer func(xesFile *os.File) {
-		_ = xesFile.Close()
-	}(xesFile) // Simplified defer statement.
-
-	// Parse the XML data.
-	xes := XES{}
-	decoder := xml.NewDecoder(xesFile)
-	if err := decoder.Decode(&xes); err != nil {
-		return fmt.Errorf("failed to decode XES file: %w", err)
+	for _, v := range a.ListAttributes {
+		attrs = append(attrs, v)
 	}
+	for _, v := range a.ContainerAttributes {
+		attrs = append(attrs, v)
+	}
+	return attrs
+}
 
-	// Collect all unique attribute keys.
-	keyMap := collectAttributeKeys(xes)
+// StringAttribute represents a string attribute in an event or trace.
+type StringAttribute struct {
+	AttrKey string `xml:"key,attr"`
+	Value   string `xml:"value,attr"`
+}
 
-	// Prepare the CSV file.
-	csvFilePath := filepath.Clean(CSVFilePath)
-	csvFile, err := os.Create(csvFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to create CSV file: %w", err)
-	}
-	defer func(csvFile *os.File) {
-		_ = csvFile.Close()
-	}(csvFile) // Simplified defer statement.
+func (a StringAttribute) Key() string         { return a.AttrKey }
+func (a StringAttribute) StringValue() string { return strings.TrimSpace(a.Value) }
+func (a StringAttribute) Type() string        { return "string" }
 
-	// Write UTF-8 BOM to ensure correct encoding.
-	if _, err := csvFile.WriteString("\xEF\xBB\xBF"); err != nil {
-		return fmt.Errorf("failed to write UTF-8 BOM: %w", err)
-	}
+// IntAttribute represents an <int> attribute in an event or trace.
+type IntAttribute struct {
+	AttrKey string `xml:"key,attr"`
+	Value   int64  `xml:"value,attr"`
+}
 
-	// Initialize CSV writer and write the header.
-	writer := csv.NewWriter(csvFile)
-	defer writer.Flush()
-	if err := writer.Write(collectHeader(keyMap)); err != nil {
-		return fmt.Errorf("failed to write CSV header: %w", err)
-	}
+func (a IntAttribute) Key() string         { return a.AttrKey }
+func (a IntAttribute) StringValue() string { return strconv.FormatInt(a.Value, 10) }
+func (a IntAttribute) Type() string        { return "int" }
 
-	// Write XES data to CSV file.
-	return writeXESToCSV(writer, xes, keyMap)
+// FloatAttribute represents a <float> attribute in an event or trace.
+type FloatAttribute struct {
+	AttrKey string  `xml:"key,attr"`
+	Value   float64 `xml:"value,attr"`
 }
 
-// isValidXESFile checks if the given file path has a .xes extension.
-func isValidXESFile(filePath string) bool {
-	return strings.HasSuffix(strings.ToLower(filePath), ".xes")
+func (a FloatAttribute) Key() string         { return a.AttrKey }
+func (a FloatAttribute) StringValue() string { return strconv.FormatFloat(a.Value, 'f', -1, 64) }
+func (a FloatAttribute) Type() string        { return "float" }
+
+// BooleanAttribute represents a <boolean> attribute in an event or trace.
+type BooleanAttribute struct {
+	AttrKey string `xml:"key,attr"`
+	Value   bool   `xml:"value,attr"`
 }
 
-// collectAttributeKeys gathers all unique attribute keys from the XES structure.
-func collectAttributeKeys(xes XES) map[string]struct{} {
-	keyMap := make(map[string]struct{})
-	for _, trace := range xes.Traces {
-		for _, attr := range trace.StringAttributes {
-			if attr.Key == "concept:name" {
-				keyMap["case:concept:name"] = struct{}{}
-			} else {
-				keyMap[attr.Key] = struct{}{}
-			}
+func (a BooleanAttribute) Key() string         { return a.AttrKey }
+func (a BooleanAttribute) StringValue() string { return strconv.FormatBool(a.Value) }
+func (a BooleanAttribute) Type() string        { return "boolean" }
+
+// IDAttribute represents an <id> attribute in an event or trace.
+type IDAttribute struct {
+	AttrKey string `xml:"key,attr"`
+	Value   string `xml:"value,attr"`
+}
+
+func (a IDAttribute) Key() string         { return a.AttrKey }
+func (a IDAttribute) StringValue() string { return strings.TrimSpace(a.Value) }
+func (a IDAttribute) Type() string        { return "id" }
+
+// DateAttribute represents a date attribute in an event.
+type DateAttribute struct {
+	AttrKey string `xml:"key,attr"`
+	Value   string `xml:"value,attr"`
+}
+
+func (a DateAttribute) Key() string         { return a.AttrKey }
+func (a DateAttribute) StringValue() string { return strings.TrimSpace(a.Value) }
+func (a DateAttribute) Type() string        { return "date" }
+
+// ListAttribute represents an ordered <list> attribute. XES nests its
+// members directly as children using the same element names any other
+// attribute container uses, so ListAttribute embeds Attributes to reuse
+// that decoding and traversal.
+type ListAttribute struct {
+	AttrKey string `xml:"key,attr"`
+	Attributes
+}
+
+func (a ListAttribute) Key() string         { return a.AttrKey }
+func (a ListAttribute) StringValue() string { return "" }
+func (a ListAttribute) Type() string        { return "list" }
+
+// ContainerAttribute represents an unordered <container> attribute, whose
+// children are addressed by key rather than by position.
+type ContainerAttribute struct {
+	AttrKey string `xml:"key,attr"`
+	Attributes
+}
+
+func (a ContainerAttribute) Key() string         { return a.AttrKey }
+func (a ContainerAttribute) StringValue() string { return "" }
+func (a ContainerAttribute) Type() string        { return "container" }
+
+// flatAttribute is a leaf attribute value paired with its fully dotted
+// column key, produced by flattening list and container attributes.
+type flatAttribute struct {
+	Key   string
+	Value string
+}
+
+// flattenAttributes expands attrs into leaf (dotted key, value) pairs,
+// recursing into list and container attributes and joining parent and
+// child keys with "." so no nested data is lost in the CSV output.
+func flattenAttributes(prefix string, attrs []Attribute) []flatAttribute {
+	var out []flatAttribute
+	for _, attr := range attrs {
+		key := attr.Key()
+		if prefix != "" {
+			key = prefix + "." + key
 		}
-		for _, event := range trace.Events {
-			for _, attr := range event.StringAttributes {
-				keyMap[attr.Key] = struct{}{}
-			}
-			for _, attr := range event.DateAttributes {
-				keyMap[attr.Key] = struct{}{}
-			}
+		switch v := attr.(type) {
+		case ListAttribute:
+			out = append(out, flattenAttributes(key, v.All())...)
+		case ContainerAttribute:
+			out = append(out, flattenAttributes(key, v.All())...)
+		default:
+			out = append(out, flatAttribute{Key: key, Value: attr.StringValue()})
 		}
 	}
-	return keyMap
+	return out
+}
+
+// ConvertXESToCSV reads an XES file and writes its contents to a CSV file.
+// It is a thin wrapper around Convert with WithFormat(FormatCSV), and
+// understands the compressed and archived forms handled by FileOption:
+// ".xes.gz", ".xes.bz2", ".xes.xz" (with WithXZDecoder), and ".zip"
+// archives containing one or more ".xes" members.
+func ConvertXESToCSV(XESFilePath, CSVFilePath string, opts ...FileOption) error {
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	inputPath := filepath.Clean(XESFilePath)
+	if !isValidXESFile(inputPath) {
+		return fmt.Errorf("input file must be an XES file: %s", inputPath)
+	}
+
+	if o.detect(inputPath) == CompressionZip {
+		return convertZipToCSV(inputPath, CSVFilePath, o)
+	}
+	return convertFileToCSV(inputPath, CSVFilePath, o)
 }
 
 // collectHeader creates the CSV header from the attribute keys.
@@ -129,45 +244,11 @@ func collectHeader(keyMap map[string]struct{}) []string {
 	return keys
 }
 
-// writeXESToCSV writes the content of the XES file to the CSV file.
-func writeXESToCSV(writer *csv.Writer, xes XES, keyMap map[string]struct{}) error {
-	keys := collectHeader(keyMap)
-	for _, trace := range xes.Traces {
-		for _, event := range trace.Events {
-			record := make([]string, len(keys))
-			for _, attr := range event.StringAttributes {
-				setAttributeValue(record, keys, attr)
-			}
-			for _, attr := range event.DateAttributes {
-				setAttributeValue(record, keys, attr)
-			}
-			for _, attr := range trace.StringAttributes {
-				if attr.Key == "concept:name" {
-					attr.Key = "case:concept:name"
-				}
-				setAttributeValue(record, keys, attr)
-			}
-			if err := writer.Write(record); err != nil {
-				return fmt.Errorf("failed to write CSV record: %w", err)
-			}
-		}
-	}
-	return nil
-}
-
-// setAttributeValue sets the value of an attribute in the CSV record.
-func setAttributeValue(record []string, keys []string, attr interface{}) {
-	switch v := attr.(type) {
-	case StringAttribute:
-		index := findIndex(keys, v.Key)
-		if index != -1 {
-			record[index] = strings.TrimSpace(v.Value)
-		}
-	case DateAttribute:
-		index := findIndex(keys, v.Key)
-		if index != -1 {
-			record[index] = strings.TrimSpace(v.Value)
-		}
+// setAttributeValue sets the value of a flattened attribute in the CSV record.
+func setAttributeValue(record []string, keys []string, attr flatAttribute) {
+	index := findIndex(keys, attr.Key)
+	if index != -1 {
+		record[index] = attr.Value
 	}
 }
 
@@ -0,0 +1,362 @@
+package xes_to_csv
+
+import (
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Compression identifies how an XES source is packaged on disk.
+type Compression int
+
+const (
+	// CompressionNone is a plain, uncompressed .xes file.
+	CompressionNone Compression = iota
+	// CompressionGzip is a .xes.gz file.
+	CompressionGzip
+	// CompressionBzip2 is a .xes.bz2 file.
+	CompressionBzip2
+	// CompressionXZ is a .xes.xz file. The standard library has no xz
+	// support, so reading it requires WithXZDecoder.
+	CompressionXZ
+	// CompressionZip is a .zip archive that may contain .xes members.
+	CompressionZip
+)
+
+// FileOption customizes how ConvertXESToCSV handles compressed and
+// archived input.
+type FileOption func(*fileOptions)
+
+type fileOptions struct {
+	sniff           func(filename string) Compression
+	xzDecoder       func(io.Reader) (io.Reader, error)
+	memberCSVName   func(member string) string
+	mergeZipMembers bool
+}
+
+// WithSniff overrides extension-based compression detection.
+func WithSniff(sniff func(filename string) Compression) FileOption {
+	return func(o *fileOptions) { o.sniff = sniff }
+}
+
+// WithXZDecoder supplies the xz decompressor to use for ".xes.xz" input.
+// The standard library has no xz support, so ConvertXESToCSV returns an
+// error for xz input unless a decoder is supplied here, for example one
+// wrapping github.com/ulikunitz/xz.
+func WithXZDecoder(decode func(io.Reader) (io.Reader, error)) FileOption {
+	return func(o *fileOptions) { o.xzDecoder = decode }
+}
+
+// WithMemberCSVName names the CSV file written for a given zip member.
+// The default replaces the member's own extension with ".csv".
+func WithMemberCSVName(name func(member string) string) FileOption {
+	return func(o *fileOptions) { o.memberCSVName = name }
+}
+
+// WithMergeZipMembers writes every .xes member of a zip archive into a
+// single CSV file, tagging each row with a "source" column naming the
+// member it came from, instead of writing one CSV file per member.
+func WithMergeZipMembers() FileOption {
+	return func(o *fileOptions) { o.mergeZipMembers = true }
+}
+
+// detect resolves filename's Compression, honoring WithSniff if set.
+func (o fileOptions) detect(filename string) Compression {
+	if o.sniff != nil {
+		return o.sniff(filename)
+	}
+	return detectCompression(filename)
+}
+
+// detectCompression maps a filename's extension to the Compression it
+// implies. XES archives are named e.g. "log.xes.gz" or "log.xes.xz".
+func detectCompression(filename string) Compression {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gz":
+		return CompressionGzip
+	case ".bz2":
+		return CompressionBzip2
+	case ".xz":
+		return CompressionXZ
+	case ".zip":
+		return CompressionZip
+	default:
+		return CompressionNone
+	}
+}
+
+// isValidXESFile reports whether filePath names something ConvertXESToCSV
+// can read: a plain ".xes" file, one of its compressed forms (".xes.gz",
+// ".xes.bz2", ".xes.xz"), or a ".zip" archive that may contain ".xes"
+// members.
+func isValidXESFile(filePath string) bool {
+	lower := strings.ToLower(filePath)
+	switch detectCompression(lower) {
+	case CompressionZip:
+		return true
+	case CompressionGzip, CompressionBzip2, CompressionXZ:
+		lower = strings.TrimSuffix(lower, filepath.Ext(lower))
+	}
+	return strings.HasSuffix(lower, ".xes")
+}
+
+// decompress wraps r according to compression, using o's XZDecoder for
+// CompressionXZ since the standard library has no xz support.
+func decompress(r io.Reader, compression Compression, o fileOptions) (io.Reader, error) {
+	switch compression {
+	case CompressionNone:
+		return r, nil
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionBzip2:
+		return bzip2.NewReader(r), nil
+	case CompressionXZ:
+		if o.xzDecoder == nil {
+			return nil, fmt.Errorf("xes_to_csv: reading .xz input requires an xz decoder; supply one with WithXZDecoder")
+		}
+		return o.xzDecoder(r)
+	default:
+		return nil, fmt.Errorf("xes_to_csv: unsupported compression %v", compression)
+	}
+}
+
+// convertFileToCSV converts a single, possibly compressed, XES file.
+func convertFileToCSV(inputPath, csvFilePath string, o fileOptions) error {
+	csvFile, err := os.Create(filepath.Clean(csvFilePath))
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer func(csvFile *os.File) {
+		_ = csvFile.Close()
+	}(csvFile)
+
+	compression := o.detect(inputPath)
+	if compression == CompressionNone {
+		xesFile, err := os.Open(inputPath)
+		if err != nil {
+			return fmt.Errorf("failed to open XES file: %w", err)
+		}
+		defer func(xesFile *os.File) {
+			_ = xesFile.Close()
+		}(xesFile)
+		return Convert(xesFile, csvFile, WithFormat(FormatCSV))
+	}
+
+	// Convert needs to make two passes over the input to discover its CSV
+	// header before writing any rows, but a gzip/bzip2 stream can't be
+	// rewound. reopenSeeker fakes an io.Seeker by reopening and
+	// re-decompressing the file whenever asked to seek back to the start.
+	seeker, err := newReopenSeeker(func() (io.ReadCloser, error) {
+		return openCompressedFile(inputPath, compression, o)
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = seeker.Close()
+	}()
+
+	return Convert(seeker, csvFile, WithFormat(FormatCSV))
+}
+
+// openCompressedFile opens inputPath and wraps it in the decompressor
+// compression implies, returning a ReadCloser that closes both.
+func openCompressedFile(inputPath string, compression Compression, o fileOptions) (io.ReadCloser, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XES file: %w", err)
+	}
+	r, err := decompress(f, compression, o)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	closers := []io.Closer{f}
+	if closer, ok := r.(io.Closer); ok {
+		closers = append([]io.Closer{closer}, closers...)
+	}
+	return &multiCloseReader{Reader: r, closers: closers}, nil
+}
+
+// multiCloseReader closes every closer, in order, when Close is called.
+type multiCloseReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *multiCloseReader) Close() error {
+	var firstErr error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// reopenSeeker adapts a family of non-seekable readers, such as gzip
+// streams, to io.Seeker by calling open again for a fresh reader whenever
+// asked to seek back to the start. That's the only seek Convert's
+// header-discovery pass needs.
+type reopenSeeker struct {
+	open    func() (io.ReadCloser, error)
+	current io.ReadCloser
+}
+
+func newReopenSeeker(open func() (io.ReadCloser, error)) (*reopenSeeker, error) {
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	return &reopenSeeker{open: open, current: r}, nil
+}
+
+func (r *reopenSeeker) Read(p []byte) (int, error) {
+	return r.current.Read(p)
+}
+
+func (r *reopenSeeker) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, fmt.Errorf("xes_to_csv: only seeking back to the start is supported")
+	}
+	if err := r.current.Close(); err != nil {
+		return 0, err
+	}
+	fresh, err := r.open()
+	if err != nil {
+		return 0, err
+	}
+	r.current = fresh
+	return 0, nil
+}
+
+func (r *reopenSeeker) Close() error {
+	return r.current.Close()
+}
+
+// convertZipToCSV converts every .xes member of a zip archive, either to
+// one CSV file per member or, with WithMergeZipMembers, one merged CSV.
+func convertZipToCSV(inputPath, csvFilePath string, o fileOptions) error {
+	zr, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+
+	var members []*zip.File
+	for _, f := range zr.File {
+		if strings.HasSuffix(strings.ToLower(f.Name), ".xes") {
+			members = append(members, f)
+		}
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("xes_to_csv: zip archive %s contains no .xes members", inputPath)
+	}
+
+	if o.mergeZipMembers {
+		return convertZipMembersMerged(members, csvFilePath)
+	}
+	baseDir := filepath.Dir(inputPath)
+	for _, member := range members {
+		if err := convertZipMemberToCSV(member, memberCSVName(o, baseDir, member.Name)); err != nil {
+			return fmt.Errorf("failed to convert zip member %s: %w", member.Name, err)
+		}
+	}
+	return nil
+}
+
+// memberCSVName names the CSV file a zip member converts to. The default
+// writes alongside the archive; a caller-supplied WithMemberCSVName is
+// trusted to return a complete path of its own choosing.
+func memberCSVName(o fileOptions, baseDir, member string) string {
+	if o.memberCSVName != nil {
+		return o.memberCSVName(member)
+	}
+	return filepath.Join(baseDir, strings.TrimSuffix(member, filepath.Ext(member))+".csv")
+}
+
+// convertZipMemberToCSV converts a single zip member to its own CSV file.
+func convertZipMemberToCSV(member *zip.File, csvPath string) error {
+	seeker, err := newReopenSeeker(func() (io.ReadCloser, error) {
+		return member.Open()
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = seeker.Close()
+	}()
+
+	csvFile, err := os.Create(filepath.Clean(csvPath))
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer func(csvFile *os.File) {
+		_ = csvFile.Close()
+	}(csvFile)
+
+	return Convert(seeker, csvFile, WithFormat(FormatCSV))
+}
+
+// convertZipMembersMerged writes every member's events into one CSV file,
+// adding a "source" column that names the member each row came from.
+func convertZipMembersMerged(members []*zip.File, csvFilePath string) error {
+	keySet := make(map[string]struct{})
+	for _, member := range members {
+		rc, err := member.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open zip member %s: %w", member.Name, err)
+		}
+		keys, err := collectEventKeys(rc)
+		_ = rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to scan zip member %s: %w", member.Name, err)
+		}
+		for _, key := range keys {
+			keySet[key] = struct{}{}
+		}
+	}
+	header := append([]string{"source"}, collectHeader(keySet)...)
+
+	csvFile, err := os.Create(filepath.Clean(csvFilePath))
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer func(csvFile *os.File) {
+		_ = csvFile.Close()
+	}(csvFile)
+
+	writer, err := newCSVEventWriter(csvFile)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, member := range members {
+		rc, err := member.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open zip member %s: %w", member.Name, err)
+		}
+		decoder := xml.NewDecoder(rc)
+		err = decodeXESStream(decoder, func(trace Trace, event Event) error {
+			row := mergeEventMap(trace, event)
+			row["source"] = member.Name
+			return writer.WriteEvent(row)
+		})
+		_ = rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to convert zip member %s: %w", member.Name, err)
+		}
+	}
+	return writer.Close()
+}
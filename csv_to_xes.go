@@ -0,0 +1,244 @@
+package xes_to_csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVOption customizes ConvertCSVToXES's behavior.
+type CSVOption func(*csvOptions)
+
+type csvOptions struct {
+	caseColumn string
+}
+
+// WithCaseColumn overrides the CSV column ConvertCSVToXES groups rows into
+// traces by. The default is "case:concept:name", matching the column
+// ConvertXESToCSV produces for a trace's own concept:name.
+func WithCaseColumn(column string) CSVOption {
+	return func(o *csvOptions) { o.caseColumn = column }
+}
+
+// standardExtensions are the XES extensions ConvertCSVToXES can recognize
+// by column prefix and declare automatically.
+var standardExtensions = []Extension{
+	{Name: "Concept", Prefix: "concept", URI: "http://www.xes-standard.org/concept.xesext"},
+	{Name: "Time", Prefix: "time", URI: "http://www.xes-standard.org/time.xesext"},
+	{Name: "Lifecycle", Prefix: "lifecycle", URI: "http://www.xes-standard.org/lifecycle.xesext"},
+	{Name: "Organizational", Prefix: "org", URI: "http://www.xes-standard.org/org.xesext"},
+}
+
+// columnType is the XES attribute element a CSV column's values will be
+// written as.
+type columnType int
+
+const (
+	columnInt columnType = iota
+	columnFloat
+	columnDate
+	columnBool
+	columnString
+)
+
+// ConvertCSVToXES reads a CSV file (with an optional UTF-8 BOM) and writes
+// a schema-valid XES document to xesPath. Rows are grouped into traces by
+// their case identifier column (WithCaseColumn, "case:concept:name" by
+// default); every other column is written as a <string>, <int>, <float>,
+// <boolean> or <date> attribute based on its inferred type. This is the
+// inverse of ConvertXESToCSV.
+func ConvertCSVToXES(csvPath, xesPath string, opts ...CSVOption) error {
+	o := csvOptions{caseColumn: "case:concept:name"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	header, rows, err := readCSV(csvPath)
+	if err != nil {
+		return err
+	}
+
+	caseIndex := findIndex(header, o.caseColumn)
+	types := inferColumnTypes(header, rows)
+
+	xes := XES{Extensions: detectExtensions(header)}
+	for _, trace := range groupRowsIntoTraces(header, rows, caseIndex, o.caseColumn, types) {
+		xes.Traces = append(xes.Traces, trace)
+	}
+
+	xesFile, err := os.Create(filepath.Clean(xesPath))
+	if err != nil {
+		return fmt.Errorf("failed to create XES file: %w", err)
+	}
+	defer func(xesFile *os.File) {
+		_ = xesFile.Close()
+	}(xesFile)
+
+	if _, err := xesFile.WriteString(xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML header: %w", err)
+	}
+	encoder := xml.NewEncoder(xesFile)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(xes); err != nil {
+		return fmt.Errorf("failed to encode XES document: %w", err)
+	}
+	return encoder.Flush()
+}
+
+// readCSV reads csvPath, stripping a UTF-8 BOM from the header's first
+// column if present, and returns the header and data rows separately.
+func readCSV(csvPath string) (header []string, rows [][]string, err error) {
+	csvFile, err := os.Open(filepath.Clean(csvPath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer func(csvFile *os.File) {
+		_ = csvFile.Close()
+	}(csvFile)
+
+	records, err := csv.NewReader(bufio.NewReader(csvFile)).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("xes_to_csv: CSV file %s has no header row", csvPath)
+	}
+
+	header = records[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	}
+	return header, records[1:], nil
+}
+
+// groupRowsIntoTraces groups rows into Traces by the value in caseIndex
+// (the caseColumn's position in header, or -1 if absent), preserving the
+// order each case value first appears in.
+func groupRowsIntoTraces(header []string, rows [][]string, caseIndex int, caseColumn string, types map[string]columnType) []Trace {
+	var order []string
+	groups := make(map[string][][]string)
+	for _, row := range rows {
+		caseValue := ""
+		if caseIndex != -1 && caseIndex < len(row) {
+			caseValue = row[caseIndex]
+		}
+		if _, seen := groups[caseValue]; !seen {
+			order = append(order, caseValue)
+		}
+		groups[caseValue] = append(groups[caseValue], row)
+	}
+
+	caseKey := strings.TrimPrefix(caseColumn, "case:")
+	traces := make([]Trace, 0, len(order))
+	for _, caseValue := range order {
+		trace := Trace{}
+		if caseIndex != -1 {
+			trace.StringAttributes = append(trace.StringAttributes, StringAttribute{AttrKey: caseKey, Value: caseValue})
+		}
+		for _, row := range groups[caseValue] {
+			var event Event
+			for i, col := range header {
+				if i == caseIndex || i >= len(row) || row[i] == "" {
+					continue
+				}
+				appendTypedAttribute(&event.Attributes, col, row[i], types[col])
+			}
+			trace.Events = append(trace.Events, event)
+		}
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+// inferColumnTypes chooses the narrowest columnType that fits every
+// non-empty value in each column, trying int, then float, then RFC3339
+// date, then bool, falling back to string.
+func inferColumnTypes(header []string, rows [][]string) map[string]columnType {
+	types := make(map[string]columnType, len(header))
+	for i, col := range header {
+		t := columnInt
+		for ; t < columnString; t++ {
+			fits := true
+			for _, row := range rows {
+				if i >= len(row) || row[i] == "" {
+					continue
+				}
+				if !fitsColumnType(t, row[i]) {
+					fits = false
+					break
+				}
+			}
+			if fits {
+				break
+			}
+		}
+		types[col] = t
+	}
+	return types
+}
+
+// fitsColumnType reports whether value parses as columnType t.
+func fitsColumnType(t columnType, value string) bool {
+	switch t {
+	case columnInt:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case columnFloat:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case columnDate:
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case columnBool:
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+// appendTypedAttribute appends key/value to attrs as the XES attribute
+// type t implies. It assumes value already fits t, as inferColumnTypes
+// guarantees for every value in that column.
+func appendTypedAttribute(attrs *Attributes, key, value string, t columnType) {
+	switch t {
+	case columnInt:
+		n, _ := strconv.ParseInt(value, 10, 64)
+		attrs.IntAttributes = append(attrs.IntAttributes, IntAttribute{AttrKey: key, Value: n})
+	case columnFloat:
+		f, _ := strconv.ParseFloat(value, 64)
+		attrs.FloatAttributes = append(attrs.FloatAttributes, FloatAttribute{AttrKey: key, Value: f})
+	case columnDate:
+		// XES dates are free-form text, not a numeric type, so the
+		// original RFC3339 string is kept as-is rather than reformatted.
+		attrs.DateAttributes = append(attrs.DateAttributes, DateAttribute{AttrKey: key, Value: value})
+	case columnBool:
+		b, _ := strconv.ParseBool(value)
+		attrs.BooleanAttributes = append(attrs.BooleanAttributes, BooleanAttribute{AttrKey: key, Value: b})
+	default:
+		attrs.StringAttributes = append(attrs.StringAttributes, StringAttribute{AttrKey: key, Value: value})
+	}
+}
+
+// detectExtensions returns the standard XES extensions implied by
+// header's column prefixes (e.g. any "concept:"-prefixed column implies
+// the Concept extension).
+func detectExtensions(header []string) []Extension {
+	var used []Extension
+	for _, ext := range standardExtensions {
+		prefix := ext.Prefix + ":"
+		for _, col := range header {
+			if strings.HasPrefix(col, prefix) || strings.HasPrefix(col, "case:"+prefix) {
+				used = append(used, ext)
+				break
+			}
+		}
+	}
+	return used
+}
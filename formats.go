@@ -0,0 +1,365 @@
+package xes_to_csv
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Format selects the output format Convert writes.
+type Format int
+
+const (
+	// FormatCSV writes one row per event, matching ConvertXESToCSV's
+	// long-standing output: nested list/container attributes flattened
+	// into dotted column names.
+	FormatCSV Format = iota
+	// FormatJSON writes a single JSON array of event objects.
+	FormatJSON
+	// FormatNDJSON writes one JSON object per line, one per event.
+	FormatNDJSON
+)
+
+// ConvertOption customizes Convert's behavior.
+type ConvertOption func(*convertOptions)
+
+type convertOptions struct {
+	format Format
+}
+
+// WithFormat selects the output format. The default is FormatCSV.
+func WithFormat(format Format) ConvertOption {
+	return func(o *convertOptions) {
+		o.format = format
+	}
+}
+
+// EventWriter writes a decoded XES event log to some destination format.
+// CSV, JSON and NDJSON are provided, and Convert chooses among these three
+// by Format; Convert does not accept a caller-supplied EventWriter.
+type EventWriter interface {
+	// WriteHeader is called once, before any WriteEvent call. For
+	// FormatCSV, Convert passes every key it found across the whole log,
+	// which the CSV writer needs up front to emit a header row; for the
+	// other formats Convert skips that scan and passes nil.
+	WriteHeader(keys []string) error
+	// WriteEvent writes a single decoded event.
+	WriteEvent(event map[string]interface{}) error
+	// Close flushes any buffered output and finalizes the format, e.g.
+	// closing a JSON array.
+	Close() error
+}
+
+// Convert decodes the XES log read from in and writes it to out in the
+// format selected by opts (FormatCSV by default). ConvertXESToCSV is a
+// thin wrapper around Convert with WithFormat(FormatCSV).
+func Convert(in io.Reader, out io.Writer, opts ...ConvertOption) error {
+	var o convertOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	writer, err := newEventWriter(out, o.format)
+	if err != nil {
+		return err
+	}
+
+	var header []string
+	if o.format == FormatCSV {
+		seeker, ok := in.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("xes_to_csv: CSV output requires a seekable reader to discover its header")
+		}
+		header, err = scanEventKeys(in, seeker)
+		if err != nil {
+			return fmt.Errorf("failed to scan event keys: %w", err)
+		}
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	decoder := xml.NewDecoder(in)
+	err = decodeXESStream(decoder, func(trace Trace, event Event) error {
+		return writer.WriteEvent(mergeEventMap(trace, event))
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// DecodeXES decodes every event in r's XES stream into a generic map keyed
+// by attribute name. Values are typed according to the XES declaration:
+// string, int64, float64, bool, time.Time for dates (a date that does not
+// parse as RFC3339 is kept as its original string), or a nested
+// []map[string]interface{} for list and container attributes. Trace-level
+// attributes are merged in under the same "case:" convention the CSV
+// writer uses, so a trace's "concept:name" surfaces as "case:concept:name".
+func DecodeXES(r io.Reader) ([]map[string]interface{}, error) {
+	var events []map[string]interface{}
+	decoder := xml.NewDecoder(r)
+	err := decodeXESStream(decoder, func(trace Trace, event Event) error {
+		events = append(events, mergeEventMap(trace, event))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// mergeEventMap combines an event's own attributes with its trace's, using
+// the same "concept:name" -> "case:concept:name" rename ConvertXESToCSV
+// has always applied to the trace identifier.
+func mergeEventMap(trace Trace, event Event) map[string]interface{} {
+	m := attributesToMap(event.All())
+	for key, value := range attributesToMap(trace.All()) {
+		if key == "concept:name" {
+			key = "case:concept:name"
+		}
+		m[key] = value
+	}
+	return m
+}
+
+// attributesToMap converts attrs to a map keyed by their own (undotted)
+// key, without regard to which container they came from.
+func attributesToMap(attrs []Attribute) map[string]interface{} {
+	m := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		m[attr.Key()] = attributeToValue(attr)
+	}
+	return m
+}
+
+// attributeToValue converts a single attribute to its typed Go value.
+func attributeToValue(attr Attribute) interface{} {
+	switch a := attr.(type) {
+	case StringAttribute:
+		return a.Value
+	case IntAttribute:
+		return a.Value
+	case FloatAttribute:
+		return a.Value
+	case BooleanAttribute:
+		return a.Value
+	case IDAttribute:
+		return a.Value
+	case DateAttribute:
+		t, err := time.Parse(time.RFC3339Nano, a.Value)
+		if err != nil {
+			return a.Value
+		}
+		return t
+	case ListAttribute:
+		return attributesToMaps(a.All())
+	case ContainerAttribute:
+		return attributesToMaps(a.All())
+	default:
+		return nil
+	}
+}
+
+// attributesToMaps converts each of attrs into its own single-key map, so
+// a list or container attribute's children survive as
+// []map[string]interface{} rather than being merged into one map and
+// losing repeated keys.
+func attributesToMaps(attrs []Attribute) []map[string]interface{} {
+	maps := make([]map[string]interface{}, 0, len(attrs))
+	for _, attr := range attrs {
+		maps = append(maps, map[string]interface{}{attr.Key(): attributeToValue(attr)})
+	}
+	return maps
+}
+
+// scanEventKeys makes a first pass over in to collect the set of dotted
+// keys FormatCSV needs for its header, then rewinds in for the real pass.
+func scanEventKeys(in io.Reader, seeker io.Seeker) ([]string, error) {
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind XES reader: %w", err)
+	}
+
+	keys, err := collectEventKeys(in)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind XES reader: %w", err)
+	}
+	return keys, nil
+}
+
+// collectEventKeys reads in to the end, returning the set of dotted event
+// keys it uses. Unlike scanEventKeys it makes no assumption about whether
+// in can be rewound, which suits sources like zip members that hand back a
+// fresh reader on each open instead.
+func collectEventKeys(in io.Reader) ([]string, error) {
+	keySet := make(map[string]struct{})
+	decoder := xml.NewDecoder(in)
+	err := decodeXESStream(decoder, func(trace Trace, event Event) error {
+		for _, flat := range flattenEventMap("", mergeEventMap(trace, event)) {
+			keySet[flat.Key] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collectHeader(keySet), nil
+}
+
+// flattenEventMap expands a decoded event map into dotted (key, value)
+// pairs, recursing into the []map[string]interface{} values that
+// represent list and container attributes.
+func flattenEventMap(prefix string, m map[string]interface{}) []flatAttribute {
+	var out []flatAttribute
+	for key, value := range m {
+		dotted := key
+		if prefix != "" {
+			dotted = prefix + "." + key
+		}
+		if children, ok := value.([]map[string]interface{}); ok {
+			for _, child := range children {
+				out = append(out, flattenEventMap(dotted, child)...)
+			}
+			continue
+		}
+		out = append(out, flatAttribute{Key: dotted, Value: formatScalarValue(value)})
+	}
+	return out
+}
+
+// formatScalarValue renders a decoded attribute value as a CSV cell.
+func formatScalarValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// newEventWriter builds the EventWriter for format, writing to w.
+func newEventWriter(w io.Writer, format Format) (EventWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEventWriter(w)
+	case FormatJSON:
+		return newJSONEventWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONEventWriter(w), nil
+	default:
+		return nil, fmt.Errorf("xes_to_csv: unsupported format %v", format)
+	}
+}
+
+// csvEventWriter is the EventWriter behind FormatCSV.
+type csvEventWriter struct {
+	writer *csv.Writer
+	header []string
+}
+
+func newCSVEventWriter(w io.Writer) (*csvEventWriter, error) {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, fmt.Errorf("failed to write UTF-8 BOM: %w", err)
+	}
+	return &csvEventWriter{writer: csv.NewWriter(w)}, nil
+}
+
+func (c *csvEventWriter) WriteHeader(header []string) error {
+	c.header = header
+	if err := c.writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	return nil
+}
+
+func (c *csvEventWriter) WriteEvent(event map[string]interface{}) error {
+	record := make([]string, len(c.header))
+	for _, flat := range flattenEventMap("", event) {
+		if idx := findIndex(c.header, flat.Key); idx != -1 {
+			record[idx] = flat.Value
+		}
+	}
+	if err := c.writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write CSV record: %w", err)
+	}
+	return nil
+}
+
+func (c *csvEventWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// ndjsonEventWriter is the EventWriter behind FormatNDJSON.
+type ndjsonEventWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEventWriter(w io.Writer) *ndjsonEventWriter {
+	return &ndjsonEventWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonEventWriter) WriteHeader([]string) error { return nil }
+
+func (n *ndjsonEventWriter) WriteEvent(event map[string]interface{}) error {
+	if err := n.enc.Encode(event); err != nil {
+		return fmt.Errorf("failed to write NDJSON event: %w", err)
+	}
+	return nil
+}
+
+func (n *ndjsonEventWriter) Close() error { return nil }
+
+// jsonEventWriter is the EventWriter behind FormatJSON. It streams a JSON
+// array so events never all need to be held in memory at once.
+type jsonEventWriter struct {
+	w     io.Writer
+	wrote bool
+}
+
+func newJSONEventWriter(w io.Writer) *jsonEventWriter {
+	return &jsonEventWriter{w: w}
+}
+
+func (j *jsonEventWriter) WriteHeader([]string) error {
+	_, err := j.w.Write([]byte("["))
+	return err
+}
+
+func (j *jsonEventWriter) WriteEvent(event map[string]interface{}) error {
+	if j.wrote {
+		if _, err := j.w.Write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	if _, err := j.w.Write(data); err != nil {
+		return err
+	}
+	j.wrote = true
+	return nil
+}
+
+func (j *jsonEventWriter) Close() error {
+	_, err := j.w.Write([]byte("]"))
+	return err
+}
@@ -0,0 +1,238 @@
+package xes_to_csv
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Option customizes the behavior of ConvertXESToCSVStream.
+type Option func(*streamOptions)
+
+type streamOptions struct {
+	header []string
+}
+
+// WithHeader supplies a fixed CSV header, letting callers skip the
+// header-discovery pass. This is required when r does not implement
+// io.Seeker, since that pass otherwise needs to rewind and re-read r.
+func WithHeader(header []string) Option {
+	return func(o *streamOptions) {
+		o.header = header
+	}
+}
+
+// ConvertXESToCSVStream reads XES from r and writes CSV to w using a
+// token-based decoder, so peak memory stays proportional to a single trace
+// rather than the whole log. This lets callers wire up os.Stdin, an HTTP
+// response body, a gzip.Reader, or any other io.Reader directly.
+//
+// Discovering the CSV header requires a first pass over the data, so r
+// must implement io.Seeker unless a header is supplied with WithHeader.
+func ConvertXESToCSVStream(r io.Reader, w io.Writer, opts ...Option) error {
+	var o streamOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	header := o.header
+	if header == nil {
+		seeker, ok := r.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("xes_to_csv: reader does not support seeking; supply a header with WithHeader")
+		}
+		var err error
+		header, err = scanHeader(r, seeker)
+		if err != nil {
+			return fmt.Errorf("failed to scan XES header: %w", err)
+		}
+	}
+
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return fmt.Errorf("failed to write UTF-8 BOM: %w", err)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	decoder := xml.NewDecoder(r)
+	if err := writeXESStream(decoder, writer, header); err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// scanHeader makes a first pass over r to collect the set of dotted
+// attribute keys the log uses, then rewinds r so the caller can decode it
+// again from the start.
+func scanHeader(r io.Reader, seeker io.Seeker) ([]string, error) {
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind XES reader: %w", err)
+	}
+
+	keyMap := make(map[string]struct{})
+	decoder := xml.NewDecoder(r)
+	err := decodeXESStream(decoder, func(trace Trace, event Event) error {
+		for _, flat := range flattenAttributes("", trace.All()) {
+			if flat.Key == "concept:name" {
+				keyMap["case:concept:name"] = struct{}{}
+			} else {
+				keyMap[flat.Key] = struct{}{}
+			}
+		}
+		for _, flat := range flattenAttributes("", event.All()) {
+			keyMap[flat.Key] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind XES reader: %w", err)
+	}
+	return collectHeader(keyMap), nil
+}
+
+// writeXESStream decodes decoder trace-by-trace, writing one CSV record per
+// event as its end element is reached.
+func writeXESStream(decoder *xml.Decoder, writer *csv.Writer, header []string) error {
+	return decodeXESStream(decoder, func(trace Trace, event Event) error {
+		record := make([]string, len(header))
+		for _, flat := range flattenAttributes("", event.All()) {
+			setAttributeValue(record, header, flat)
+		}
+		for _, flat := range flattenAttributes("", trace.All()) {
+			if flat.Key == "concept:name" {
+				flat.Key = "case:concept:name"
+			}
+			setAttributeValue(record, header, flat)
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+		return nil
+	})
+}
+
+// decodeXESStream walks decoder's token stream, calling onEvent for every
+// <event> found inside a <trace>, without ever materializing more than one
+// trace's attributes and one event at a time.
+func decodeXESStream(decoder *xml.Decoder, onEvent func(Trace, Event) error) error {
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read XES token: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "trace" {
+			continue
+		}
+		if err := decodeTraceStream(decoder, start, onEvent); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeTraceStream reads one <trace> element, from startTok to its
+// matching end element, calling onEvent as each contained <event> is fully
+// decoded. XES lists a trace's own attributes before its events, so by the
+// time the first event is seen trace.Attributes is already complete.
+func decodeTraceStream(decoder *xml.Decoder, startTok xml.StartElement, onEvent func(Trace, Event) error) error {
+	var trace Trace
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read trace token: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "event" {
+				var event Event
+				if err := decoder.DecodeElement(&event, &t); err != nil {
+					return fmt.Errorf("failed to decode event: %w", err)
+				}
+				if err := onEvent(trace, event); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := decodeAttributeInto(decoder, t, &trace.Attributes); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if t.Name.Local == startTok.Name.Local {
+				return nil
+			}
+		}
+	}
+}
+
+// decodeAttributeInto decodes a single attribute start element into attrs,
+// dispatching on the element's local name. Elements that aren't XES
+// attributes are skipped rather than treated as an error, matching how
+// encoding/xml's whole-document decoding silently ignores unknown fields.
+func decodeAttributeInto(decoder *xml.Decoder, start xml.StartElement, attrs *Attributes) error {
+	switch start.Name.Local {
+	case "string":
+		var a StringAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.StringAttributes = append(attrs.StringAttributes, a)
+	case "int":
+		var a IntAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.IntAttributes = append(attrs.IntAttributes, a)
+	case "float":
+		var a FloatAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.FloatAttributes = append(attrs.FloatAttributes, a)
+	case "boolean":
+		var a BooleanAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.BooleanAttributes = append(attrs.BooleanAttributes, a)
+	case "id":
+		var a IDAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.IDAttributes = append(attrs.IDAttributes, a)
+	case "date":
+		var a DateAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.DateAttributes = append(attrs.DateAttributes, a)
+	case "list":
+		var a ListAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.ListAttributes = append(attrs.ListAttributes, a)
+	case "container":
+		var a ContainerAttribute
+		if err := decoder.DecodeElement(&a, &start); err != nil {
+			return err
+		}
+		attrs.ContainerAttributes = append(attrs.ContainerAttributes, a)
+	default:
+		return decoder.Skip()
+	}
+	return nil
+}
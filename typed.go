@@ -0,0 +1,297 @@
+package xes_to_csv
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// EventIterator decodes one event at a time from an XES stream into
+// caller-supplied structs whose fields carry `xes:"..."` tags, e.g.
+//
+//	type Row struct {
+//		Case      string    `xes:"case:concept:name"`
+//		Activity  string    `xes:"concept:name"`
+//		Timestamp time.Time `xes:"time:timestamp"`
+//	}
+//
+// It is built on the same token walk as ConvertXESToCSVStream, so peak
+// memory stays proportional to a single trace.
+type EventIterator struct {
+	decoder      *xml.Decoder
+	inTrace      bool
+	currentTrace Trace
+	err          error
+}
+
+// NewEventIterator returns an EventIterator reading from r.
+func NewEventIterator(r io.Reader) *EventIterator {
+	return &EventIterator{decoder: xml.NewDecoder(r)}
+}
+
+// Next decodes the next event into row, which must be a pointer to a
+// struct. It returns false once the stream is exhausted or a decode error
+// occurs; call Err afterward to distinguish the two.
+func (it *EventIterator) Next(row interface{}) bool {
+	for {
+		tok, err := it.decoder.Token()
+		if err == io.EOF {
+			return false
+		}
+		if err != nil {
+			it.err = fmt.Errorf("failed to read XES token: %w", err)
+			return false
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !it.inTrace {
+				if t.Name.Local == "trace" {
+					it.inTrace = true
+					it.currentTrace = Trace{}
+				}
+				continue
+			}
+			if t.Name.Local == "event" {
+				var event Event
+				if err := it.decoder.DecodeElement(&event, &t); err != nil {
+					it.err = fmt.Errorf("failed to decode event: %w", err)
+					return false
+				}
+				if err := decodeRow(it.currentTrace, event, row); err != nil {
+					it.err = err
+					return false
+				}
+				return true
+			}
+			if err := decodeAttributeInto(it.decoder, t, &it.currentTrace.Attributes); err != nil {
+				it.err = err
+				return false
+			}
+		case xml.EndElement:
+			if it.inTrace && t.Name.Local == "trace" {
+				it.inTrace = false
+			}
+		}
+	}
+}
+
+// Err returns the error, if any, that stopped the most recent Next call.
+func (it *EventIterator) Err() error {
+	return it.err
+}
+
+// Unmarshal decodes every event in r's XES stream into out, which must be
+// a pointer to a slice of structs using `xes:"..."` field tags. It is a
+// convenience wrapper around EventIterator for callers who want the whole
+// log in memory as typed rows rather than CSV.
+func Unmarshal(r io.Reader, out interface{}) error {
+	outVal := reflect.ValueOf(out)
+	if outVal.Kind() != reflect.Ptr || outVal.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("xes_to_csv: out must be a pointer to a slice, got %T", out)
+	}
+	sliceVal := outVal.Elem()
+	elemType := sliceVal.Type().Elem()
+
+	it := NewEventIterator(r)
+	for {
+		rowPtr := reflect.New(elemType)
+		if !it.Next(rowPtr.Interface()) {
+			break
+		}
+		sliceVal.Set(reflect.Append(sliceVal, rowPtr.Elem()))
+	}
+	return it.Err()
+}
+
+// decodeRow flattens trace and event attributes into a single dotted-key
+// value lookup, then fills every `xes`-tagged field of row from it.
+func decodeRow(trace Trace, event Event, row interface{}) error {
+	v := reflect.ValueOf(row)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("xes_to_csv: row must be a pointer to a struct, got %T", row)
+	}
+
+	values := make(map[string]flatAttribute)
+	for _, flat := range flattenAttributes("", trace.All()) {
+		if flat.Key == "concept:name" {
+			flat.Key = "case:concept:name"
+		}
+		values[flat.Key] = flat
+	}
+	for _, flat := range flattenAttributes("", event.All()) {
+		values[flat.Key] = flat
+	}
+
+	elem := v.Elem()
+	t := elem.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("xes")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		flat, ok := values[tag]
+		if !ok {
+			continue
+		}
+		if err := setFieldValue(elem.Field(i), flat.Value); err != nil {
+			return fmt.Errorf("xes_to_csv: field %s: %w", t.Field(i).Name, err)
+		}
+	}
+	return nil
+}
+
+// setFieldValue parses value into field according to field's Go type,
+// treating time.Time specially since XES dates are formatted, not numeric.
+func setFieldValue(field reflect.Value, value string) error {
+	if field.Type() == timeType {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("parsing XES date %q: %w", value, err)
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}
+
+// Marshal is the inverse of Unmarshal: it groups rows into traces by their
+// "case:"-tagged field, if any, and writes a well-formed XES document to w.
+// Field values become <string>, <int>, <float>, <boolean> or <date>
+// attributes based on the field's Go type; time.Time fields are formatted
+// as RFC3339Nano dates.
+func Marshal(rows interface{}, w io.Writer) error {
+	rv := reflect.ValueOf(rows)
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("xes_to_csv: rows must be a slice, got %T", rows)
+	}
+	elemType := rv.Type().Elem()
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("xes_to_csv: rows must be a slice of structs, got %T", rows)
+	}
+
+	caseField := -1
+	for i := 0; i < elemType.NumField(); i++ {
+		if strings.HasPrefix(elemType.Field(i).Tag.Get("xes"), "case:") {
+			caseField = i
+			break
+		}
+	}
+
+	var order []string
+	groups := make(map[string][]reflect.Value)
+	for i := 0; i < rv.Len(); i++ {
+		row := rv.Index(i)
+		caseValue := ""
+		if caseField != -1 {
+			caseValue = fmt.Sprint(row.Field(caseField).Interface())
+		}
+		if _, seen := groups[caseValue]; !seen {
+			order = append(order, caseValue)
+		}
+		groups[caseValue] = append(groups[caseValue], row)
+	}
+
+	xes := XES{}
+	for _, caseValue := range order {
+		trace := Trace{}
+		if caseField != -1 {
+			key := strings.TrimPrefix(elemType.Field(caseField).Tag.Get("xes"), "case:")
+			trace.StringAttributes = append(trace.StringAttributes, StringAttribute{AttrKey: key, Value: caseValue})
+		}
+		for _, row := range groups[caseValue] {
+			var event Event
+			for i := 0; i < elemType.NumField(); i++ {
+				if i == caseField {
+					continue
+				}
+				tag := elemType.Field(i).Tag.Get("xes")
+				if tag == "" || tag == "-" {
+					continue
+				}
+				attr, err := fieldToAttribute(tag, row.Field(i))
+				if err != nil {
+					return fmt.Errorf("xes_to_csv: field %s: %w", elemType.Field(i).Name, err)
+				}
+				appendAttribute(&event.Attributes, attr)
+			}
+			trace.Events = append(trace.Events, event)
+		}
+		xes.Traces = append(xes.Traces, trace)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(xes); err != nil {
+		return fmt.Errorf("failed to encode XES document: %w", err)
+	}
+	return encoder.Flush()
+}
+
+// fieldToAttribute builds the concrete Attribute implementation matching
+// field's Go type, so Marshal round-trips whatever Unmarshal produced.
+func fieldToAttribute(key string, field reflect.Value) (Attribute, error) {
+	if field.Type() == timeType {
+		return DateAttribute{AttrKey: key, Value: field.Interface().(time.Time).Format(time.RFC3339Nano)}, nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return StringAttribute{AttrKey: key, Value: field.String()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntAttribute{AttrKey: key, Value: field.Int()}, nil
+	case reflect.Float32, reflect.Float64:
+		return FloatAttribute{AttrKey: key, Value: field.Float()}, nil
+	case reflect.Bool:
+		return BooleanAttribute{AttrKey: key, Value: field.Bool()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+}
+
+// appendAttribute appends attr to attrs' slice matching its concrete type.
+func appendAttribute(attrs *Attributes, attr Attribute) {
+	switch a := attr.(type) {
+	case StringAttribute:
+		attrs.StringAttributes = append(attrs.StringAttributes, a)
+	case IntAttribute:
+		attrs.IntAttributes = append(attrs.IntAttributes, a)
+	case FloatAttribute:
+		attrs.FloatAttributes = append(attrs.FloatAttributes, a)
+	case BooleanAttribute:
+		attrs.BooleanAttributes = append(attrs.BooleanAttributes, a)
+	case DateAttribute:
+		attrs.DateAttributes = append(attrs.DateAttributes, a)
+	}
+}